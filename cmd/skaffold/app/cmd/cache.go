@@ -0,0 +1,132 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/flags"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+	runcontext "github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner/context"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// NewCmdCache returns the "cache" command and its subcommands.
+func NewCmdCache(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Interact with skaffold's build cache",
+	}
+	cmd.AddCommand(NewCmdCacheWarm(out))
+	return cmd
+}
+
+var (
+	cacheWarmImages []string
+	cacheWarmDryRun bool
+)
+
+// NewCmdCacheWarm returns the "cache warm" command: pull every image that
+// already has a remote cache entry into the local Docker daemon, so a
+// following skaffold dev/build hits the local daemon path immediately
+// instead of rebuilding.
+func NewCmdCacheWarm(out io.Writer) *cobra.Command {
+	opts := &runcontext.SkaffoldOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Warm the build cache for a list of images",
+		Long: "Pull every image in the list that already has an entry in the " +
+			"configured --cache-repo into the local Docker daemon, so a " +
+			"following skaffold dev/build doesn't have to rebuild it. Images " +
+			"are taken from --images, or read one per line from stdin if " +
+			"--images isn't set.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			images := cacheWarmImages
+			if len(images) == 0 {
+				var err error
+				images, err = readImagesFromStdin(os.Stdin)
+				if err != nil {
+					return err
+				}
+			}
+
+			return warmCache(out, opts, images, cacheWarmDryRun)
+		},
+	}
+
+	flags.AddCacheFlags(cmd.Flags(), opts)
+	cmd.Flags().StringSliceVar(&cacheWarmImages, "images", nil, "images to warm the cache for (reads from stdin, one per line, if not set)")
+	cmd.Flags().BoolVar(&cacheWarmDryRun, "dry-run", false, "print which images would be warmed without pulling them")
+
+	return cmd
+}
+
+// readImagesFromStdin reads one image name per non-empty line from in.
+func readImagesFromStdin(in io.Reader) ([]string, error) {
+	var images []string
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if image := scanner.Text(); image != "" {
+			images = append(images, image)
+		}
+	}
+
+	return images, scanner.Err()
+}
+
+// imageNameDependencyLister treats each artifact's own image name as its
+// only dependency, so two different images always hash to two different
+// cache keys. It's a minimal stand-in for the project's real
+// build-dependency resolver (used by skaffold dev/build to compute the
+// cache key from actual source files) so that cache warm has something to
+// hash images by; swap it out once that resolver is wired up for this
+// command too.
+type imageNameDependencyLister struct{}
+
+func (imageNameDependencyLister) DependenciesForArtifact(_ context.Context, artifact *latest.Artifact) ([]string, error) {
+	return []string{artifact.ImageName}, nil
+}
+
+// warmCache builds a minimal artifact list out of images and runs it
+// through the configured cache's Warm. imagesAreLocal is false: warm only
+// ever needs the remote cache repo, so a runner without a local Docker
+// daemon (the CI case --cache-repo exists for) still warms instead of
+// failing to even start.
+func warmCache(out io.Writer, opts *runcontext.SkaffoldOptions, images []string, dryRun bool) error {
+	opts.CacheArtifacts = true
+
+	runCtx := &runcontext.RunContext{Opts: *opts}
+
+	c, err := cache.NewCache(runCtx, false, imageNameDependencyLister{})
+	if err != nil {
+		return err
+	}
+
+	artifacts := make([]*latest.Artifact, len(images))
+	for i, image := range images {
+		artifacts[i] = &latest.Artifact{ImageName: image, Workspace: "."}
+	}
+
+	return c.Warm(context.Background(), out, artifacts, dryRun)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"github.com/spf13/pflag"
+
+	runcontext "github.com/GoogleContainerTools/skaffold/pkg/skaffold/runner/context"
+)
+
+// AddCacheFlags registers the cache-related CLI flags onto opts.
+func AddCacheFlags(flags *pflag.FlagSet, opts *runcontext.SkaffoldOptions) {
+	flags.StringVar(&opts.CacheRepo, "cache-repo", "",
+		"a remote repo to mirror the artifact cache into (e.g. gcr.io/team/skaffold-cache), so concurrent skaffold invocations such as CI runners can share cache hits")
+	flags.StringVar(&opts.CacheBackend, "cache-backend", "",
+		`in-memory cache backend to front the persisted cache file, "lru" or "" to disable`)
+	flags.IntVar(&opts.CacheMaxEntries, "cache-max-entries", 0,
+		"maximum number of entries kept in the in-memory cache backend (0 is unbounded)")
+	flags.Int64Var(&opts.CacheMaxSize, "cache-max-size", 0,
+		"maximum total byte size of the in-memory cache backend (0 is unbounded)")
+	flags.DurationVar(&opts.CacheTTL, "cache-ttl", 0,
+		"drop cache entries older than this duration, whether held in-memory or on disk (0 disables expiry)")
+}
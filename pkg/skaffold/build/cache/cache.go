@@ -18,8 +18,11 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
@@ -41,6 +44,15 @@ var (
 type ImageDetails struct {
 	Digest string `yaml:"digest,omitempty"`
 	ID     string `yaml:"id,omitempty"`
+
+	// CreatedAt is when this entry was written, used to expire entries
+	// older than the configured --cache-ttl.
+	CreatedAt time.Time `yaml:"createdAt,omitempty"`
+	// ConfigDigest is the sha256 of the image's raw config as last seen by
+	// Set. On load it's recomputed from the live image and compared, so an
+	// image rebuilt out-of-band (e.g. by another tool) can't silently
+	// poison the cache.
+	ConfigDigest string `yaml:"configDigest,omitempty"`
 }
 
 // ArtifactCache is a map of [artifact dependencies hash : ImageDetails]
@@ -48,12 +60,13 @@ type ArtifactCache map[string]ImageDetails
 
 // cache holds any data necessary for accessing the cache
 type cache struct {
-	artifactCache      ArtifactCache
+	store              Store
 	dependencies       DependencyLister
 	client             docker.LocalDaemon
 	insecureRegistries map[string]bool
 	cacheFile          string
 	imagesAreLocal     bool
+	remote             *remoteStore
 }
 
 // DependencyLister fetches a list of dependencies for an artifact
@@ -73,25 +86,74 @@ func NewCache(runCtx *runcontext.RunContext, imagesAreLocal bool, dependencies D
 		return &noCache{}, nil
 	}
 
-	artifactCache, err := retrieveArtifactCache(cacheFile)
+	client, err := newDockerClient(runCtx)
+	if imagesAreLocal && err != nil {
+		return nil, errors.Wrap(err, "getting local Docker client")
+	}
+
+	artifactCache, err := retrieveArtifactCache(cacheFile, client, runCtx.Opts.CacheTTL)
 	if err != nil {
 		logrus.Warnf("Error retrieving artifact cache, not using skaffold cache: %v", err)
 		return &noCache{}, nil
 	}
 
-	client, err := newDockerClient(runCtx)
-	if imagesAreLocal && err != nil {
-		return nil, errors.Wrap(err, "getting local Docker client")
-	}
+	store := newStore(cacheFile, artifactCache, storeConfig{
+		backend:    runCtx.Opts.CacheBackend,
+		maxEntries: runCtx.Opts.CacheMaxEntries,
+		maxBytes:   runCtx.Opts.CacheMaxSize,
+		ttl:        runCtx.Opts.CacheTTL,
+	})
 
-	return &cache{
-		artifactCache:      artifactCache,
+	c := &cache{
+		store:              store,
 		dependencies:       dependencies,
 		client:             client,
 		insecureRegistries: runCtx.InsecureRegistries,
 		cacheFile:          cacheFile,
 		imagesAreLocal:     imagesAreLocal,
-	}, nil
+	}
+
+	if runCtx.Opts.CacheRepo != "" {
+		c.remote = newRemoteStore(runCtx.Opts.CacheRepo)
+	}
+
+	return c, nil
+}
+
+// lookup returns the ImageDetails for hash, preferring a shared remote cache
+// entry, if one is configured, over the locally persisted cache. This lets
+// CI runners that don't share ~/.skaffold/cache still hit on artifacts built
+// by a previous run.
+func (c *cache) lookup(hash string) (ImageDetails, bool) {
+	if c.remote != nil {
+		if details, found := c.remote.lookup(hash); found {
+			return details, true
+		}
+	}
+
+	return c.store.Get(hash)
+}
+
+// set persists details for hash, stamping CreatedAt and, when the image is
+// still resolvable through client, a ConfigDigest computed from its raw
+// config. A later retrieveArtifactCache compares against ConfigDigest to
+// detect entries that went stale because the image was rebuilt out-of-band.
+func (c *cache) set(ctx context.Context, hash string, details ImageDetails) {
+	details.CreatedAt = time.Now()
+
+	if c.client != nil {
+		if _, raw, err := c.client.ImageInspectWithRaw(ctx, details.ID); err == nil {
+			details.ConfigDigest = fmt.Sprintf("%x", sha256.Sum256(raw))
+		}
+	}
+
+	c.store.Set(hash, details)
+
+	if c.remote != nil {
+		if err := c.remote.store(hash, details); err != nil {
+			logrus.Warnf("couldn't mirror cache entry for %s to remote repo: %v", hash, err)
+		}
+	}
 }
 
 func createDockerClient(runCtx *runcontext.RunContext) (docker.LocalDaemon, error) {
@@ -111,16 +173,44 @@ func resolveCacheFile(cacheFile string) (string, error) {
 	return defaultFile, util.VerifyOrCreateFile(defaultFile)
 }
 
-func retrieveArtifactCache(cacheFile string) (ArtifactCache, error) {
-	cache := ArtifactCache{}
+// retrieveArtifactCache loads cacheFile and drops any entry older than
+// maxAge (when maxAge > 0), or whose recorded ConfigDigest no longer
+// matches the live image's raw config, so that images rebuilt out-of-band
+// or left to go stale can't silently poison the cache.
+func retrieveArtifactCache(cacheFile string, client docker.LocalDaemon, maxAge time.Duration) (ArtifactCache, error) {
 	contents, err := ioutil.ReadFile(cacheFile)
 	if err != nil {
 		return nil, err
 	}
-	if err := yaml.Unmarshal(contents, &cache); err != nil {
+
+	loaded := ArtifactCache{}
+	if err := yaml.Unmarshal(contents, &loaded); err != nil {
 		return nil, err
 	}
-	return cache, nil
+
+	verified := ArtifactCache{}
+	for hash, details := range loaded {
+		if maxAge > 0 && !details.CreatedAt.IsZero() && time.Since(details.CreatedAt) > maxAge {
+			logrus.Debugf("dropping expired cache entry for %s (created %s)", hash, details.CreatedAt)
+			continue
+		}
+
+		if client != nil && details.ConfigDigest != "" {
+			_, raw, err := client.ImageInspectWithRaw(context.Background(), details.ID)
+			if err != nil {
+				logrus.Debugf("dropping cache entry for %s, image %s no longer found: %v", hash, details.ID, err)
+				continue
+			}
+			if digest := fmt.Sprintf("%x", sha256.Sum256(raw)); digest != details.ConfigDigest {
+				logrus.Debugf("dropping cache entry for %s, config digest changed", hash)
+				continue
+			}
+		}
+
+		verified[hash] = details
+	}
+
+	return verified, nil
 }
 
 func saveArtifactCache(cacheFile string, contents ArtifactCache) error {
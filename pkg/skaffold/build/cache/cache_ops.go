@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+// RetrieveCachedArtifactDetails hashes artifact's dependencies and looks up
+// the resulting key, preferring a shared remote cache entry over the
+// locally persisted one. A builder calls this before building an artifact
+// to decide whether it can skip the build entirely.
+func (c *cache) RetrieveCachedArtifactDetails(ctx context.Context, artifact *latest.Artifact) (ImageDetails, bool, error) {
+	deps, err := c.dependencies.DependenciesForArtifact(ctx, artifact)
+	if err != nil {
+		return ImageDetails{}, false, err
+	}
+
+	hash := hashDependencies(deps)
+	details, found := c.lookup(hash)
+	return details, found, nil
+}
+
+// CacheArtifact records details as the cache entry for artifact's current
+// dependencies, so a later RetrieveCachedArtifactDetails call hits. A
+// builder calls this right after successfully building an artifact.
+func (c *cache) CacheArtifact(ctx context.Context, artifact *latest.Artifact, details ImageDetails) error {
+	deps, err := c.dependencies.DependenciesForArtifact(ctx, artifact)
+	if err != nil {
+		return err
+	}
+
+	hash := hashDependencies(deps)
+	c.set(ctx, hash, details)
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func writeTestCacheFile(t *testing.T, contents ArtifactCache) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "skaffold-cache-test")
+	if err != nil {
+		t.Fatalf("creating temp cache file: %v", err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	if err := saveArtifactCache(f.Name(), contents); err != nil {
+		t.Fatalf("saveArtifactCache: %v", err)
+	}
+	return f.Name()
+}
+
+func TestRetrieveArtifactCacheDropsExpiredEntries(t *testing.T) {
+	cacheFile := writeTestCacheFile(t, ArtifactCache{
+		"fresh":   {Digest: "sha256:fresh", ID: "sha256:fresh", CreatedAt: time.Now()},
+		"expired": {Digest: "sha256:old", ID: "sha256:old", CreatedAt: time.Now().Add(-48 * time.Hour)},
+	})
+
+	got, err := retrieveArtifactCache(cacheFile, nil, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("retrieveArtifactCache: %v", err)
+	}
+
+	if _, ok := got["fresh"]; !ok {
+		t.Error("expected fresh entry to survive")
+	}
+	if _, ok := got["expired"]; ok {
+		t.Error("expected expired entry to be dropped")
+	}
+}
+
+func TestRetrieveArtifactCacheDropsMismatchedConfigDigest(t *testing.T) {
+	imageID := "sha256:abc"
+	rawConfig := []byte(fmt.Sprintf(`{"Config":{"Image":"%s"}}`, imageID))
+	liveDigest := fmt.Sprintf("%x", sha256.Sum256(rawConfig))
+
+	fakeAPI := &testutil.FakeAPIClient{
+		TagToImageID: map[string]string{imageID: imageID},
+	}
+	client := &testutil.FakeLocalDaemon{FakeAPIClient: fakeAPI}
+
+	cacheFile := writeTestCacheFile(t, ArtifactCache{
+		"matches":   {Digest: "sha256:d1", ID: imageID, CreatedAt: time.Now(), ConfigDigest: liveDigest},
+		"stale":     {Digest: "sha256:d2", ID: imageID, CreatedAt: time.Now(), ConfigDigest: "sha256:nolongermatches"},
+		"goneImage": {Digest: "sha256:d3", ID: "sha256:doesnotexist", CreatedAt: time.Now(), ConfigDigest: "sha256:whatever"},
+	})
+
+	got, err := retrieveArtifactCache(cacheFile, client, 0)
+	if err != nil {
+		t.Fatalf("retrieveArtifactCache: %v", err)
+	}
+
+	if _, ok := got["matches"]; !ok {
+		t.Error("expected entry whose ConfigDigest matches the live image to survive")
+	}
+	if _, ok := got["stale"]; ok {
+		t.Error("expected entry with a stale ConfigDigest to be dropped")
+	}
+	if _, ok := got["goneImage"]; ok {
+		t.Error("expected entry for an image that no longer exists to be dropped")
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// hashDependencies is the single, canonical artifact-hash key derivation
+// used everywhere an ImageDetails lookup or write needs a hash: build-time
+// cache hits/misses (RetrieveCachedArtifactDetails/CacheArtifact) and
+// cache warm (Warm) both call this, so warming a key always matches the
+// key a real build would have looked up.
+func hashDependencies(deps []string) string {
+	sorted := append([]string(nil), deps...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, dep := range sorted {
+		io.WriteString(h, dep)
+		io.WriteString(h, "\x00")
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
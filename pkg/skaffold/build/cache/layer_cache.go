@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// LayerCache caches individual Dockerfile instruction layers, keyed by a
+// hash of the instruction and the file-digest of the inputs it depends on.
+// This lets a builder skip re-executing a RUN or COPY step and instead
+// append the previously produced layer straight onto the in-progress image.
+type LayerCache interface {
+	RetrieveLayer(key string) (v1.Image, error)
+	StoreLayer(key string, img v1.Image) error
+}
+
+// NotFoundErr is returned by RetrieveLayer when key has no cached entry.
+type NotFoundErr struct {
+	key string
+}
+
+func (e NotFoundErr) Error() string {
+	return fmt.Sprintf("no cached layer for key %q", e.key)
+}
+
+// ExpiredErr is returned by RetrieveLayer when key has a cached entry, but
+// it is older than the store's configured TTL.
+type ExpiredErr struct {
+	key       string
+	createdAt time.Time
+}
+
+func (e ExpiredErr) Error() string {
+	return fmt.Sprintf("cached layer for key %q expired (created %s)", e.key, e.createdAt)
+}
+
+// IsNotFound reports whether err is a NotFoundErr, so builders can fall
+// through to executing the instruction normally.
+func IsNotFound(err error) bool {
+	_, ok := err.(NotFoundErr)
+	return ok
+}
+
+// IsExpired reports whether err is an ExpiredErr, so builders can refresh
+// the entry instead of treating it as a hard failure.
+func IsExpired(err error) bool {
+	_, ok := err.(ExpiredErr)
+	return ok
+}
+
+// layerEntry is the on-disk/remote record wrapping a cached layer.
+type layerEntry struct {
+	createdAt time.Time
+	ttl       time.Duration
+}
+
+func (e layerEntry) expired(now time.Time) bool {
+	return e.ttl > 0 && now.Sub(e.createdAt) > e.ttl
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"path/filepath"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// DefaultLayerCacheDir is the on-disk OCI layout store used by
+// localLayerCache when no directory is configured.
+const DefaultLayerCacheDir = ".skaffold/layer-cache"
+
+// localLayerCache stores cached layers as images in an OCI layout on disk,
+// under dir. Each entry is annotated with its creation time so that expired
+// entries can be detected without a side index.
+type localLayerCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewLocalLayerCache returns a LayerCache backed by an OCI layout on disk.
+// If dir is empty, it defaults to ~/.skaffold/layer-cache.
+func NewLocalLayerCache(dir string, ttl time.Duration) (LayerCache, error) {
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, errors.Wrap(err, "retrieving home directory")
+		}
+		dir = filepath.Join(home, DefaultLayerCacheDir)
+	}
+
+	if _, err := layout.FromPath(dir); err != nil {
+		if _, err := layout.Write(dir, empty.Index); err != nil {
+			return nil, errors.Wrap(err, "initializing layer cache layout")
+		}
+	}
+
+	return &localLayerCache{dir: dir, ttl: ttl}, nil
+}
+
+func (l *localLayerCache) RetrieveLayer(key string) (v1.Image, error) {
+	path, err := layout.FromPath(l.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening layer cache layout")
+	}
+
+	idx, err := path.ImageIndex()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading layer cache index")
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading layer cache manifest")
+	}
+
+	for _, desc := range manifest.Manifests {
+		if desc.Annotations["key"] != key {
+			continue
+		}
+
+		createdAt, _ := time.Parse(time.RFC3339, desc.Annotations["createdAt"])
+		entry := layerEntry{createdAt: createdAt, ttl: l.ttl}
+		if entry.expired(timeNow()) {
+			return nil, ExpiredErr{key: key, createdAt: createdAt}
+		}
+
+		return idx.Image(desc.Digest)
+	}
+
+	return nil, NotFoundErr{key: key}
+}
+
+func (l *localLayerCache) StoreLayer(key string, img v1.Image) error {
+	path, err := layout.FromPath(l.dir)
+	if err != nil {
+		return errors.Wrap(err, "opening layer cache layout")
+	}
+
+	// Remove any prior entry for key first, so a refresh after an
+	// ExpiredErr actually replaces the stale manifest instead of leaving it
+	// as an earlier, and therefore still-returned, match in the index.
+	if err := path.RemoveDescriptors(match.Annotation("key", key)); err != nil {
+		return errors.Wrap(err, "removing stale layer cache entry")
+	}
+
+	return path.AppendImage(img, layout.WithAnnotations(map[string]string{
+		"key":       key,
+		"createdAt": timeNow().Format(time.RFC3339),
+	}))
+}
+
+// timeNow is a seam so tests can control expiry without sleeping.
+var timeNow = func() time.Time { return time.Now() }
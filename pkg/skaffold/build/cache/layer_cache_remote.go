@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// annotationLayerCachedAt is the manifest annotation remoteLayerCache stamps
+// with the time a layer was cached. TTL expiry must be judged against this,
+// not the layer image's own Created field: output.timestamp: Zero pins
+// Created to the UNIX epoch, which would otherwise read back as maximally
+// expired the instant the layer is stored.
+const annotationLayerCachedAt = "cache.skaffold.dev/cachedAt"
+
+// remoteLayerCache tags cached layers into a configured registry repo, so
+// that layers warmed on one machine (e.g. a CI cache-warming step) can be
+// pulled down and appended by builds running elsewhere.
+type remoteLayerCache struct {
+	repo string
+	ttl  time.Duration
+}
+
+// NewRemoteLayerCache returns a LayerCache that mirrors layers into repo as
+// cache-<key> tagged images.
+func NewRemoteLayerCache(repo string, ttl time.Duration) LayerCache {
+	return &remoteLayerCache{repo: repo, ttl: ttl}
+}
+
+func (r *remoteLayerCache) RetrieveLayer(key string) (v1.Image, error) {
+	tag, err := cacheTag(r.repo, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "building layer cache reference")
+	}
+
+	img, err := remote.Image(tag, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, NotFoundErr{key: key}
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cached layer manifest")
+	}
+
+	createdAt, _ := time.Parse(time.RFC3339, manifest.Annotations[annotationLayerCachedAt])
+	entry := layerEntry{createdAt: createdAt, ttl: r.ttl}
+	if entry.expired(timeNow()) {
+		return nil, ExpiredErr{key: key, createdAt: createdAt}
+	}
+
+	return img, nil
+}
+
+func (r *remoteLayerCache) StoreLayer(key string, img v1.Image) error {
+	tag, err := cacheTag(r.repo, key)
+	if err != nil {
+		return errors.Wrap(err, "building layer cache reference")
+	}
+
+	annotated, ok := mutate.Annotations(img, map[string]string{
+		annotationLayerCachedAt: timeNow().Format(time.RFC3339),
+	}).(v1.Image)
+	if !ok {
+		return errors.New("annotating cached layer image")
+	}
+
+	return remote.Write(tag, annotated, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+}
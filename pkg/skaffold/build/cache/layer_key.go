@@ -0,0 +1,36 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// LayerKey hashes a single Dockerfile instruction (e.g. a RUN or COPY line)
+// together with the digest of the files it reads from the build context and
+// chainDigest, the digest of the image the instruction runs against. Mixing
+// in chainDigest is what makes the key specific to this instruction's
+// position in this Dockerfile, built from this base: two Dockerfiles (or the
+// same Dockerfile after a FROM bump) that happen to repeat one
+// instruction+context-digest pair only collide if they also share the exact
+// same image up to that point, which is precisely when reusing the cached
+// layer is correct.
+func LayerKey(chainDigest, command, contextDigest string) string {
+	h := sha256.Sum256([]byte(chainDigest + "\x00" + command + "\x00" + contextDigest))
+	return fmt.Sprintf("%x", h)
+}
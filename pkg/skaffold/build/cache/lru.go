@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry is the value stored in the LRU's doubly-linked list.
+type lruEntry struct {
+	hash      string
+	details   ImageDetails
+	size      int64
+	createdAt time.Time
+}
+
+// lruStore is a bounded, in-memory cache with an LRU eviction policy plus a
+// per-entry TTL, so server-mode skaffold and long-lived dev loops don't
+// accumulate entries forever. Admission is cost-based on the serialized
+// size of the entry, similar in spirit to ristretto, but kept simple: once
+// either maxEntries or maxBytes is exceeded, the least-recently-used
+// entries are evicted until the cache is back under both limits.
+type lruStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	curBytes   int64
+	ll         *list.List
+	index      map[string]*list.Element
+}
+
+func newLRUStore(maxEntries int, maxBytes int64, ttl time.Duration) *lruStore {
+	return &lruStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		ll:         list.New(),
+		index:      make(map[string]*list.Element),
+	}
+}
+
+func entrySize(details ImageDetails) int64 {
+	return int64(len(details.Digest) + len(details.ID))
+}
+
+func (c *lruStore) Get(hash string) (ImageDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.index[hash]
+	if !ok {
+		return ImageDetails{}, false
+	}
+
+	entry := el.Value.(*lruEntry)
+	if c.ttl > 0 && time.Since(entry.createdAt) > c.ttl {
+		c.removeElement(el)
+		return ImageDetails{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	return entry.details, true
+}
+
+func (c *lruStore) Set(hash string, details ImageDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[hash]; ok {
+		c.removeElement(el)
+	}
+
+	entry := &lruEntry{hash: hash, details: details, size: entrySize(details), createdAt: time.Now()}
+	el := c.ll.PushFront(entry)
+	c.index[hash] = el
+	c.curBytes += entry.size
+
+	c.evictIfNeeded()
+}
+
+func (c *lruStore) Evict(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[hash]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *lruStore) evictIfNeeded() {
+	for (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) || (c.maxBytes > 0 && c.curBytes > c.maxBytes) {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			return
+		}
+		c.removeElement(oldest)
+	}
+}
+
+func (c *lruStore) removeElement(el *list.Element) {
+	entry := el.Value.(*lruEntry)
+	c.ll.Remove(el)
+	delete(c.index, entry.hash)
+	c.curBytes -= entry.size
+}
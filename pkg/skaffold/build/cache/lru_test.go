@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUStoreGetSetRoundTrip(t *testing.T) {
+	c := newLRUStore(0, 0, 0)
+
+	c.Set("a", ImageDetails{Digest: "sha256:a"})
+
+	got, found := c.Get("a")
+	if !found {
+		t.Fatal("expected a hit for a key that was just set")
+	}
+	if got.Digest != "sha256:a" {
+		t.Errorf("Get returned %+v, want digest sha256:a", got)
+	}
+
+	if _, found := c.Get("missing"); found {
+		t.Error("expected a miss for a key that was never set")
+	}
+}
+
+func TestLRUStoreEvictsLeastRecentlyUsedOnMaxEntries(t *testing.T) {
+	c := newLRUStore(2, 0, 0)
+
+	c.Set("a", ImageDetails{Digest: "sha256:a"})
+	c.Set("b", ImageDetails{Digest: "sha256:b"})
+
+	// Touch "a" so "b" becomes the least-recently-used entry.
+	c.Get("a")
+
+	c.Set("c", ImageDetails{Digest: "sha256:c"})
+
+	if _, found := c.Get("b"); found {
+		t.Error("expected b to be evicted as the least-recently-used entry")
+	}
+	if _, found := c.Get("a"); !found {
+		t.Error("expected a to survive eviction, it was used more recently than b")
+	}
+	if _, found := c.Get("c"); !found {
+		t.Error("expected c, the entry that triggered eviction, to be present")
+	}
+}
+
+func TestLRUStoreEvictsOnMaxBytes(t *testing.T) {
+	// entrySize is len(Digest)+len(ID); each entry below is 10 bytes.
+	c := newLRUStore(0, 15, 0)
+
+	c.Set("a", ImageDetails{Digest: "0123456789"})
+	c.Set("b", ImageDetails{Digest: "0123456789"})
+
+	if _, found := c.Get("a"); found {
+		t.Error("expected a to be evicted once total size exceeded maxBytes")
+	}
+	if _, found := c.Get("b"); !found {
+		t.Error("expected b, the most recent entry, to survive")
+	}
+}
+
+func TestLRUStoreTTLExpiry(t *testing.T) {
+	c := newLRUStore(0, 0, time.Millisecond)
+	c.Set("a", ImageDetails{Digest: "sha256:a"})
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.Get("a"); found {
+		t.Error("expected entry older than the TTL to be treated as a miss")
+	}
+}
+
+func TestLRUStoreEvict(t *testing.T) {
+	c := newLRUStore(0, 0, 0)
+	c.Set("a", ImageDetails{Digest: "sha256:a"})
+
+	c.Evict("a")
+
+	if _, found := c.Get("a"); found {
+		t.Error("expected explicitly evicted entry to be gone")
+	}
+}
+
+func TestLRUStoreSetOverwritesExistingEntry(t *testing.T) {
+	c := newLRUStore(0, 0, 0)
+	c.Set("a", ImageDetails{Digest: "sha256:old"})
+	c.Set("a", ImageDetails{Digest: "sha256:new"})
+
+	got, found := c.Get("a")
+	if !found {
+		t.Fatal("expected a hit")
+	}
+	if got.Digest != "sha256:new" {
+		t.Errorf("Get returned %+v, want the overwritten digest sha256:new", got)
+	}
+	if c.ll.Len() != 1 {
+		t.Errorf("expected a single list entry after overwrite, got %d", c.ll.Len())
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// Annotations on the cache-<hash> placeholder image that carry the real
+// artifact's digest and ID. The placeholder itself is just a carrier: its
+// own manifest digest and config hash are never meaningful and must never
+// be returned from lookup.
+const (
+	annotationDigest = "cache.skaffold.dev/digest"
+	annotationID     = "cache.skaffold.dev/id"
+)
+
+// RegistryClient abstracts the remote-registry calls the cache's remote
+// store makes, so tests can substitute a fake (testutil.FakeAPIClient
+// implements it) instead of talking to a real registry.
+type RegistryClient interface {
+	// Lookup resolves the artifact digest/id mirrored under hash in repo,
+	// if any.
+	Lookup(repo, hash string) (digest, id string, found bool)
+	// Store mirrors digest/id under hash in repo.
+	Store(repo, hash, digest, id string) error
+}
+
+// ociRegistryClient is the production RegistryClient, backed by
+// google/go-containerregistry.
+type ociRegistryClient struct{}
+
+// Lookup resolves whether hash already has a corresponding entry in repo,
+// returning the artifact's own digest/id as recorded in the placeholder's
+// annotations. It only fetches the manifest, never the layers, so a lookup
+// is cheap even for large images.
+func (ociRegistryClient) Lookup(repo, hash string) (string, string, bool) {
+	tag, err := cacheTag(repo, hash)
+	if err != nil {
+		return "", "", false
+	}
+
+	img, err := remote.Image(tag, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", "", false
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return "", "", false
+	}
+
+	digest, ok := manifest.Annotations[annotationDigest]
+	if !ok {
+		return "", "", false
+	}
+
+	return digest, manifest.Annotations[annotationID], true
+}
+
+// Store mirrors digest/id into repo as a zero-layer placeholder image
+// tagged cache-<hash>, carrying the real values as annotations. Other
+// skaffold invocations can resolve them with a single remote.Image call
+// instead of talking to a local Docker daemon.
+func (ociRegistryClient) Store(repo, hash, digest, id string) error {
+	tag, err := cacheTag(repo, hash)
+	if err != nil {
+		return errors.Wrap(err, "building cache-repo reference")
+	}
+
+	img, ok := mutate.Annotations(empty.Image, map[string]string{
+		annotationDigest: digest,
+		annotationID:     id,
+	}).(v1.Image)
+	if !ok {
+		return errors.New("annotating cache placeholder image")
+	}
+
+	return remote.Write(tag, img, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+}
+
+// cacheTag returns the reference used to mirror hash's ImageDetails in repo.
+func cacheTag(repo, hash string) (name.Tag, error) {
+	return name.NewTag(fmt.Sprintf("%s:cache-%s", repo, hash))
+}
+
+// remoteStore mirrors ArtifactCache entries into a shared registry
+// repository (--cache-repo), so that concurrent skaffold invocations, e.g.
+// CI runners that don't share a filesystem, can still share cache hits.
+type remoteStore struct {
+	repo     string
+	registry RegistryClient
+}
+
+// newRemoteStore returns a remoteStore backed by a real registry.
+func newRemoteStore(repo string) *remoteStore {
+	return &remoteStore{repo: repo, registry: ociRegistryClient{}}
+}
+
+// lookup returns the artifact's own ImageDetails, exactly as passed to a
+// previous store call, never anything derived from the placeholder image
+// itself (its manifest digest and config hash are meaningless carriers).
+func (r remoteStore) lookup(hash string) (ImageDetails, bool) {
+	digest, id, found := r.registry.Lookup(r.repo, hash)
+	if !found {
+		return ImageDetails{}, false
+	}
+
+	return ImageDetails{Digest: digest, ID: id}, true
+}
+
+func (r remoteStore) store(hash string, details ImageDetails) error {
+	return r.registry.Store(r.repo, hash, details.Digest, details.ID)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRemoteStoreRoundTrip(t *testing.T) {
+	fake := &testutil.FakeAPIClient{}
+	r := remoteStore{repo: "gcr.io/team/cache", registry: fake}
+
+	if err := r.store("hash1", ImageDetails{Digest: "gcr.io/team/app@sha256:real", ID: "sha256:abc"}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	details, found := r.lookup("hash1")
+	if !found {
+		t.Fatal("expected a cache hit after store")
+	}
+	if details.Digest != "gcr.io/team/app@sha256:real" {
+		t.Errorf("lookup returned digest %q, want the real artifact digest passed to store", details.Digest)
+	}
+	if details.ID != "sha256:abc" {
+		t.Errorf("lookup returned ID %q, want %q", details.ID, "sha256:abc")
+	}
+}
+
+func TestRemoteStoreLookupMiss(t *testing.T) {
+	r := remoteStore{repo: "gcr.io/team/cache", registry: &testutil.FakeAPIClient{}}
+
+	if _, found := r.lookup("missing"); found {
+		t.Error("expected a miss for a hash that was never stored")
+	}
+}
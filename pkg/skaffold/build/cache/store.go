@@ -0,0 +1,117 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Store is the persistence backend for artifact-hash -> ImageDetails
+// entries. ArtifactCache remains the on-disk interchange format; Store is
+// what the cache actually reads and writes through, so backends can be
+// swapped (or layered) without touching the rest of the package.
+type Store interface {
+	Get(hash string) (ImageDetails, bool)
+	Set(hash string, details ImageDetails)
+	Evict(hash string)
+}
+
+// yamlStore persists entries to cacheFile as YAML, matching the cache's
+// original, single-developer-machine behavior.
+type yamlStore struct {
+	cacheFile string
+	entries   ArtifactCache
+}
+
+func newYAMLStore(cacheFile string, entries ArtifactCache) *yamlStore {
+	return &yamlStore{cacheFile: cacheFile, entries: entries}
+}
+
+func (s *yamlStore) Get(hash string) (ImageDetails, bool) {
+	details, found := s.entries[hash]
+	return details, found
+}
+
+func (s *yamlStore) Set(hash string, details ImageDetails) {
+	s.entries[hash] = details
+	if err := saveArtifactCache(s.cacheFile, s.entries); err != nil {
+		logrus.Warnf("couldn't save cache file %s: %v", s.cacheFile, err)
+	}
+}
+
+func (s *yamlStore) Evict(hash string) {
+	delete(s.entries, hash)
+	if err := saveArtifactCache(s.cacheFile, s.entries); err != nil {
+		logrus.Warnf("couldn't save cache file %s: %v", s.cacheFile, err)
+	}
+}
+
+// writeThroughStore serves reads from front, falling back to back on a
+// miss, and writes to both on Set/Evict. It's used to put a bounded,
+// in-memory LRU (front) ahead of the unbounded YAML store (back), so
+// long-lived dev loops and skaffold's server mode don't grow the resident
+// cache without bound, while a restart still has the persisted entries to
+// warm from.
+type writeThroughStore struct {
+	front Store
+	back  Store
+}
+
+func (s *writeThroughStore) Get(hash string) (ImageDetails, bool) {
+	if details, found := s.front.Get(hash); found {
+		return details, true
+	}
+
+	details, found := s.back.Get(hash)
+	if found {
+		s.front.Set(hash, details)
+	}
+	return details, found
+}
+
+func (s *writeThroughStore) Set(hash string, details ImageDetails) {
+	s.front.Set(hash, details)
+	s.back.Set(hash, details)
+}
+
+func (s *writeThroughStore) Evict(hash string) {
+	s.front.Evict(hash)
+	s.back.Evict(hash)
+}
+
+// storeConfig configures the in-memory backend that fronts the persistent
+// YAML store.
+type storeConfig struct {
+	backend    string // "lru" or "" (disabled)
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+}
+
+func newStore(cacheFile string, entries ArtifactCache, cfg storeConfig) Store {
+	back := newYAMLStore(cacheFile, entries)
+	if cfg.backend != "lru" {
+		return back
+	}
+
+	return &writeThroughStore{
+		front: newLRUStore(cfg.maxEntries, cfg.maxBytes, cfg.ttl),
+		back:  back,
+	}
+}
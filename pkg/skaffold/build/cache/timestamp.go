@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// OutputTimestamp controls what Created timestamp a built image (and its
+// layer history) gets, which in turn determines whether its digest, and so
+// its cache key, is stable across rebuilds from identical inputs.
+type OutputTimestamp string
+
+const (
+	// Zero sets Created to the UNIX epoch, matching what reproducible build
+	// tools like buildah/Bazel typically use.
+	Zero OutputTimestamp = "Zero"
+	// SourceTimestamp sets Created to the max mtime of the build context, or
+	// the HEAD commit time if the context is a git tree.
+	SourceTimestamp OutputTimestamp = "SourceTimestamp"
+	// BuildTimestamp sets Created to the current time. This is the legacy,
+	// non-reproducible default.
+	BuildTimestamp OutputTimestamp = "BuildTimestamp"
+)
+
+// OutputTimestampValueNotSupportedErr is returned when output.timestamp is
+// set to a value other than Zero, SourceTimestamp or BuildTimestamp.
+type OutputTimestampValueNotSupportedErr struct {
+	value string
+}
+
+func (e OutputTimestampValueNotSupportedErr) Error() string {
+	return fmt.Sprintf("unsupported output.timestamp value %q: must be one of Zero, SourceTimestamp, BuildTimestamp", e.value)
+}
+
+// ResolveOutputTimestamp validates value and resolves it to the concrete
+// time that should be written into the image config and layer history.
+// sourceTime is the caller-computed timestamp to use for SourceTimestamp
+// (the max mtime of the build context, or the context's git commit time).
+func ResolveOutputTimestamp(value string, sourceTime time.Time) (time.Time, error) {
+	switch OutputTimestamp(value) {
+	case "", BuildTimestamp:
+		return time.Now(), nil
+	case Zero:
+		return time.Unix(0, 0), nil
+	case SourceTimestamp:
+		return sourceTime, nil
+	default:
+		return time.Time{}, OutputTimestampValueNotSupportedErr{value: value}
+	}
+}
+
+// ApplyOutputTimestamp rewrites img's config Created field and each layer
+// history entry's created time to createdAt, via go-containerregistry's
+// mutate helpers, so that two builds from identical inputs produce the
+// identical digest regardless of wall-clock time.
+func ApplyOutputTimestamp(img v1.Image, createdAt time.Time) (v1.Image, error) {
+	img, err := mutate.CreatedAt(img, v1.Time{Time: createdAt})
+	if err != nil {
+		return nil, err
+	}
+	return mutate.Time(img, createdAt)
+}
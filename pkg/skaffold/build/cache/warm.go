@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/sirupsen/logrus"
+)
+
+// Warm walks artifacts, computes each one's dependency-hash key, and for
+// any key that already has an entry in the configured remote cache repo,
+// pulls the corresponding image into the local Docker daemon. A following
+// skaffold dev/build then hits the local daemon path immediately instead of
+// rebuilding. Individual pull failures are logged and skipped rather than
+// aborting the whole warm-up. With dryRun, it only reports which keys would
+// be fetched.
+func (c *cache) Warm(ctx context.Context, out io.Writer, artifacts []*latest.Artifact, dryRun bool) error {
+	if c.remote == nil {
+		logrus.Warnln("cache warm requires --cache-repo, skipping")
+		return nil
+	}
+
+	for _, artifact := range artifacts {
+		deps, err := c.dependencies.DependenciesForArtifact(ctx, artifact)
+		if err != nil {
+			logrus.Warnf("couldn't compute dependencies for %s, skipping: %v", artifact.ImageName, err)
+			continue
+		}
+
+		hash := hashDependencies(deps)
+
+		details, found := c.remote.lookup(hash)
+		if !found {
+			continue
+		}
+
+		if dryRun {
+			fmt.Fprintf(out, "would warm %s from remote cache (%s)\n", artifact.ImageName, details.Digest)
+			continue
+		}
+
+		if c.client == nil {
+			logrus.Warnf("no local Docker client available, skipping warm of %s", details.Digest)
+			continue
+		}
+
+		if err := c.client.Pull(ctx, out, details.Digest); err != nil {
+			logrus.Warnf("couldn't pull %s for cache warming, skipping: %v", details.Digest, err)
+			continue
+		}
+
+		fmt.Fprintf(out, "warmed %s from remote cache (%s)\n", artifact.ImageName, details.Digest)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type fakeDependencyLister map[string][]string
+
+func (f fakeDependencyLister) DependenciesForArtifact(_ context.Context, artifact *latest.Artifact) ([]string, error) {
+	return f[artifact.ImageName], nil
+}
+
+func TestWarmPullsOnRemoteHit(t *testing.T) {
+	deps := fakeDependencyLister{"warmed": {"a.go", "b.go"}}
+	hash := hashDependencies(deps["warmed"])
+
+	fakeAPI := &testutil.FakeAPIClient{}
+	if err := fakeAPI.Store("gcr.io/team/cache", hash, "gcr.io/team/app@sha256:real", "sha256:abc"); err != nil {
+		t.Fatalf("seeding remote store: %v", err)
+	}
+
+	c := &cache{
+		remote:       &remoteStore{repo: "gcr.io/team/cache", registry: fakeAPI},
+		dependencies: deps,
+		client:       &testutil.FakeLocalDaemon{FakeAPIClient: fakeAPI},
+	}
+
+	var out bytes.Buffer
+	if err := c.Warm(context.Background(), &out, []*latest.Artifact{{ImageName: "warmed"}}, false); err != nil {
+		t.Fatalf("Warm: %v", err)
+	}
+
+	if len(fakeAPI.Pulled) != 1 || fakeAPI.Pulled[0] != "gcr.io/team/app@sha256:real" {
+		t.Errorf("Pulled = %v, want a single pull of the real artifact digest", fakeAPI.Pulled)
+	}
+}
+
+func TestWarmSkipsOnRemoteMiss(t *testing.T) {
+	deps := fakeDependencyLister{"cold": {"a.go"}}
+	fakeAPI := &testutil.FakeAPIClient{}
+
+	c := &cache{
+		remote:       &remoteStore{repo: "gcr.io/team/cache", registry: fakeAPI},
+		dependencies: deps,
+		client:       &testutil.FakeLocalDaemon{FakeAPIClient: fakeAPI},
+	}
+
+	var out bytes.Buffer
+	if err := c.Warm(context.Background(), &out, []*latest.Artifact{{ImageName: "cold"}}, false); err != nil {
+		t.Fatalf("Warm: %v", err)
+	}
+
+	if len(fakeAPI.Pulled) != 0 {
+		t.Errorf("Pulled = %v, want no pulls for a cache miss", fakeAPI.Pulled)
+	}
+}
+
+func TestWarmDryRunDoesNotPull(t *testing.T) {
+	deps := fakeDependencyLister{"warmed": {"a.go"}}
+	hash := hashDependencies(deps["warmed"])
+
+	fakeAPI := &testutil.FakeAPIClient{}
+	if err := fakeAPI.Store("gcr.io/team/cache", hash, "gcr.io/team/app@sha256:real", "sha256:abc"); err != nil {
+		t.Fatalf("seeding remote store: %v", err)
+	}
+
+	c := &cache{
+		remote:       &remoteStore{repo: "gcr.io/team/cache", registry: fakeAPI},
+		dependencies: deps,
+		client:       &testutil.FakeLocalDaemon{FakeAPIClient: fakeAPI},
+	}
+
+	var out bytes.Buffer
+	if err := c.Warm(context.Background(), &out, []*latest.Artifact{{ImageName: "warmed"}}, true); err != nil {
+		t.Fatalf("Warm: %v", err)
+	}
+
+	if len(fakeAPI.Pulled) != 0 {
+		t.Errorf("Pulled = %v, want no pulls in dry-run", fakeAPI.Pulled)
+	}
+}
+
+func TestWarmWithoutRemoteConfigured(t *testing.T) {
+	c := &cache{}
+
+	var out bytes.Buffer
+	if err := c.Warm(context.Background(), &out, []*latest.Artifact{{ImageName: "anything"}}, false); err != nil {
+		t.Fatalf("Warm: %v", err)
+	}
+}
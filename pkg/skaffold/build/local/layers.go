@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+)
+
+// Instruction is a single Dockerfile RUN/COPY step, identified by its
+// command text and the digest of the context files it reads.
+type Instruction struct {
+	Command       string
+	ContextDigest string
+}
+
+// BuildWithLayerCache builds artifact instruction-by-instruction, serving
+// each RUN/COPY step from layerCache when possible instead of re-executing
+// it, then tags the resulting image as tag. execute actually runs a single
+// instruction against the in-progress image (e.g. by invoking the Docker
+// daemon on a single-step Dockerfile).
+func (b *Builder) BuildWithLayerCache(base v1.Image, instructions []Instruction, layerCache cache.LayerCache, execute func(v1.Image, Instruction) (v1.Image, error)) (v1.Image, error) {
+	return applyLayerCache(base, instructions, layerCache, execute)
+}
+
+// applyLayerCache walks instructions starting from base, and for each one
+// already in layerCache, grafts the cached layer onto the in-progress image
+// instead of calling execute. A miss (NotFoundErr) or a stale hit
+// (ExpiredErr) both fall through to execute, and the result is stored back
+// so the next build can hit.
+//
+// The cache key mixes in the digest of img as it stands before this
+// instruction (see cache.LayerKey), so a hit only ever happens against the
+// exact base and instruction history the cached layer was built from. On a
+// hit, only that layer is appended onto img (never img = cached wholesale)
+// so a keying mistake can't silently swap img's own lineage out from under
+// the build.
+func applyLayerCache(base v1.Image, instructions []Instruction, layerCache cache.LayerCache, execute func(v1.Image, Instruction) (v1.Image, error)) (v1.Image, error) {
+	img := base
+
+	for _, instr := range instructions {
+		chainDigest, err := img.Digest()
+		if err != nil {
+			return nil, errors.Wrap(err, "hashing in-progress image")
+		}
+		key := cache.LayerKey(chainDigest.String(), instr.Command, instr.ContextDigest)
+
+		cached, err := layerCache.RetrieveLayer(key)
+		if err == nil {
+			appended, err := appendCachedLayer(img, cached)
+			if err != nil {
+				return nil, err
+			}
+			img = appended
+			continue
+		}
+		if !cache.IsNotFound(err) && !cache.IsExpired(err) {
+			return nil, err
+		}
+
+		built, err := execute(img, instr)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := layerCache.StoreLayer(key, built); err != nil {
+			logrus.Warnf("couldn't cache layer for %q: %v", instr.Command, err)
+		}
+
+		img = built
+	}
+
+	return img, nil
+}
+
+// appendCachedLayer appends the single layer cached added on top of img's
+// own digest (guaranteed by the chain-digest-keyed lookup in
+// applyLayerCache) onto img, rather than substituting cached for img
+// wholesale.
+func appendCachedLayer(img, cached v1.Image) (v1.Image, error) {
+	layers, err := cached.Layers()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cached layer image's layers")
+	}
+	if len(layers) == 0 {
+		return nil, errors.New("cached layer image has no layers")
+	}
+
+	return mutate.AppendLayers(img, layers[len(layers)-1])
+}
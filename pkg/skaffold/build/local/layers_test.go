@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+)
+
+// fakeLayerCache is an in-memory cache.LayerCache for tests.
+type fakeLayerCache map[string]v1.Image
+
+func (f fakeLayerCache) RetrieveLayer(key string) (v1.Image, error) {
+	img, ok := f[key]
+	if !ok {
+		return nil, cache.NotFoundErr{}
+	}
+	return img, nil
+}
+
+func (f fakeLayerCache) StoreLayer(key string, img v1.Image) error {
+	f[key] = img
+	return nil
+}
+
+func mustRandomImage(t *testing.T) v1.Image {
+	t.Helper()
+	img, err := random.Image(50, 1)
+	if err != nil {
+		t.Fatalf("building random image: %v", err)
+	}
+	return img
+}
+
+func appendRandomLayer(img v1.Image) (v1.Image, error) {
+	extra, err := random.Image(50, 1)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := extra.Layers()
+	if err != nil {
+		return nil, err
+	}
+	return mutate.AppendLayers(img, layers[0])
+}
+
+// TestApplyLayerCacheDoesNotPoisonAcrossDifferentBases reproduces the bug
+// the old command+contextDigest-only key allowed: two builds sharing the
+// exact same instruction text and context digest, but starting from
+// different base images, must never share a cache entry, and a hit must
+// graft the cached layer onto the current image rather than substitute the
+// cached image's own (potentially unrelated) lineage for it.
+func TestApplyLayerCacheDoesNotPoisonAcrossDifferentBases(t *testing.T) {
+	base1 := mustRandomImage(t)
+	base2 := mustRandomImage(t)
+
+	layerCache := fakeLayerCache{}
+	instr := Instruction{Command: "RUN echo hi", ContextDigest: "same-context-digest"}
+
+	executed := 0
+	execute := func(img v1.Image, _ Instruction) (v1.Image, error) {
+		executed++
+		return appendRandomLayer(img)
+	}
+
+	final1, err := applyLayerCache(base1, []Instruction{instr}, layerCache, execute)
+	if err != nil {
+		t.Fatalf("build against base1: %v", err)
+	}
+	if executed != 1 {
+		t.Fatalf("expected execute to run once building base1 the first time, ran %d times", executed)
+	}
+
+	base1Layers, _ := base1.Layers()
+	final1Layers, _ := final1.Layers()
+	if len(final1Layers) != len(base1Layers)+1 {
+		t.Fatalf("expected final1 to have base1's layer plus one new layer, got %d layers", len(final1Layers))
+	}
+
+	// base2 shares the exact same command+contextDigest as base1's build,
+	// the scenario that used to collide under the old command-only key. It
+	// must still execute (its chain digest differs from base1's), and the
+	// result must be built on top of base2, never base1.
+	final2, err := applyLayerCache(base2, []Instruction{instr}, layerCache, execute)
+	if err != nil {
+		t.Fatalf("build against base2: %v", err)
+	}
+	if executed != 2 {
+		t.Fatalf("expected a same-command/context build against a different base to still execute, execute ran %d times total", executed)
+	}
+
+	base2Layers, _ := base2.Layers()
+	final2Layers, _ := final2.Layers()
+	if len(final2Layers) != len(base2Layers)+1 {
+		t.Fatalf("expected final2 to have base2's layer plus one new layer, got %d layers", len(final2Layers))
+	}
+
+	base2Digest, _ := base2Layers[0].Digest()
+	final2BaseDigest, _ := final2Layers[0].Digest()
+	if final2BaseDigest != base2Digest {
+		t.Error("expected final2's base layer to be base2's own layer, not swapped for base1's")
+	}
+
+	// Rebuilding against base1 again must now hit the cache and skip execute.
+	rebuilt1, err := applyLayerCache(base1, []Instruction{instr}, layerCache, execute)
+	if err != nil {
+		t.Fatalf("rebuild against base1: %v", err)
+	}
+	if executed != 2 {
+		t.Errorf("expected the second base1 build to hit the cache, execute ran an extra time (total %d)", executed)
+	}
+
+	rebuilt1Layers, _ := rebuilt1.Layers()
+	if len(rebuilt1Layers) != len(final1Layers) {
+		t.Errorf("expected the cache-hit rebuild to reproduce final1's layer count (%d), got %d", len(final1Layers), len(rebuilt1Layers))
+	}
+}
+
+func TestApplyLayerCacheMultiInstructionChain(t *testing.T) {
+	base := mustRandomImage(t)
+	layerCache := fakeLayerCache{}
+
+	instructions := []Instruction{
+		{Command: "RUN first", ContextDigest: "ctx"},
+		{Command: "RUN second", ContextDigest: "ctx"},
+	}
+
+	executed := 0
+	execute := func(img v1.Image, _ Instruction) (v1.Image, error) {
+		executed++
+		return appendRandomLayer(img)
+	}
+
+	final, err := applyLayerCache(base, instructions, layerCache, execute)
+	if err != nil {
+		t.Fatalf("first build: %v", err)
+	}
+	if executed != 2 {
+		t.Fatalf("expected both instructions to execute on a cold cache, executed %d times", executed)
+	}
+
+	baseLayers, _ := base.Layers()
+	finalLayers, _ := final.Layers()
+	if len(finalLayers) != len(baseLayers)+2 {
+		t.Fatalf("expected base's layer plus two new layers, got %d layers", len(finalLayers))
+	}
+
+	if _, err := applyLayerCache(base, instructions, layerCache, execute); err != nil {
+		t.Fatalf("second build: %v", err)
+	}
+	if executed != 2 {
+		t.Errorf("expected an identical rebuild to hit the cache for both instructions, executed %d times total", executed)
+	}
+}
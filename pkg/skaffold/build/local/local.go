@@ -0,0 +1,285 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/pkg/errors"
+)
+
+// Builder builds artifacts with the local Docker daemon.
+type Builder struct {
+	localDocker docker.LocalDaemon
+	layerCache  cache.LayerCache
+}
+
+// NewBuilder returns a Builder that builds with localDocker. layerCache is
+// optional: pass nil to always build the whole Dockerfile in one
+// localDocker.Build call. When set, Build instead runs the Dockerfile
+// instruction-by-instruction through BuildWithLayerCache, so a RUN/COPY step
+// that's already cached for the current base and history is grafted on
+// instead of re-executed.
+func NewBuilder(localDocker docker.LocalDaemon, layerCache cache.LayerCache) *Builder {
+	return &Builder{localDocker: localDocker, layerCache: layerCache}
+}
+
+// Build runs artifact's Dockerfile through the local Docker daemon, then
+// applies artifact.Output.Timestamp, rewriting the built image's Created
+// field and layer history so that rebuilding from identical inputs produces
+// an identical digest.
+func (b *Builder) Build(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) (string, error) {
+	if b.layerCache != nil {
+		if err := b.buildWithLayerCache(ctx, out, artifact, tag); err != nil {
+			return "", errors.Wrap(err, "building image with layer cache")
+		}
+	} else if err := b.localDocker.Build(ctx, out, artifact.Workspace, tag); err != nil {
+		return "", errors.Wrap(err, "building image")
+	}
+
+	sourceTime, err := sourceTimestamp(artifact.Workspace)
+	if err != nil {
+		return "", errors.Wrap(err, "computing source timestamp")
+	}
+
+	createdAt, err := cache.ResolveOutputTimestamp(artifact.Output.Timestamp, sourceTime)
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing tag")
+	}
+
+	img, err := daemon.Image(ref)
+	if err != nil {
+		return "", errors.Wrap(err, "reading built image back from the daemon")
+	}
+
+	img, err = cache.ApplyOutputTimestamp(img, createdAt)
+	if err != nil {
+		return "", errors.Wrap(err, "applying output.timestamp")
+	}
+
+	if _, err := daemon.Write(ref, img); err != nil {
+		return "", errors.Wrap(err, "retagging image with output.timestamp applied")
+	}
+
+	return tag, nil
+}
+
+// sourceTimestamp resolves output.timestamp: SourceTimestamp for workspace:
+// the HEAD commit time if workspace is a git tree (has a .git directory), or
+// else the max mtime of its files.
+func sourceTimestamp(workspace string) (time.Time, error) {
+	if _, err := os.Stat(filepath.Join(workspace, ".git")); err == nil {
+		return gitHeadTimestamp(workspace)
+	} else if !os.IsNotExist(err) {
+		return time.Time{}, err
+	}
+
+	return maxModTime(workspace)
+}
+
+// gitHeadTimestamp returns the commit time of workspace's HEAD.
+func gitHeadTimestamp(workspace string) (time.Time, error) {
+	out, err := exec.Command("git", "-C", workspace, "log", "-1", "--format=%cI").Output()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "reading HEAD commit time")
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
+
+// maxModTime returns the latest mtime among the files under workspace.
+func maxModTime(workspace string) (time.Time, error) {
+	var latest time.Time
+
+	err := filepath.Walk(workspace, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+
+	return latest, err
+}
+
+// buildWithLayerCache parses artifact's Dockerfile, runs it instruction by
+// instruction through BuildWithLayerCache so already-cached RUN/COPY steps
+// are grafted on instead of re-executed, and writes the final result into
+// the local daemon as tag.
+func (b *Builder) buildWithLayerCache(ctx context.Context, out io.Writer, artifact *latest.Artifact, tag string) error {
+	fromImage, instructions, err := parseDockerfile(filepath.Join(artifact.Workspace, "Dockerfile"))
+	if err != nil {
+		return errors.Wrap(err, "parsing Dockerfile")
+	}
+
+	ctxDigest, err := contextDigest(artifact.Workspace)
+	if err != nil {
+		return errors.Wrap(err, "hashing build context")
+	}
+	for i := range instructions {
+		instructions[i].ContextDigest = ctxDigest
+	}
+
+	fromRef, err := name.ParseReference(fromImage)
+	if err != nil {
+		return errors.Wrap(err, "parsing FROM image")
+	}
+
+	base, err := daemon.Image(fromRef)
+	if err != nil {
+		return errors.Wrap(err, "reading FROM image from the daemon")
+	}
+
+	final, err := b.BuildWithLayerCache(base, instructions, b.layerCache, func(img v1.Image, instr Instruction) (v1.Image, error) {
+		return b.runInstruction(ctx, out, artifact.Workspace, img, instr)
+	})
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		return errors.Wrap(err, "parsing tag")
+	}
+
+	_, err = daemon.Write(ref, final)
+	return err
+}
+
+// runInstruction executes a single Dockerfile instruction against base by
+// staging base into the local daemon under a throwaway tag and building a
+// single-instruction Dockerfile FROM it, then reads the result back.
+func (b *Builder) runInstruction(ctx context.Context, out io.Writer, workspace string, base v1.Image, instr Instruction) (v1.Image, error) {
+	baseDigest, err := base.Digest()
+	if err != nil {
+		return nil, errors.Wrap(err, "hashing base image")
+	}
+
+	baseTag := fmt.Sprintf("skaffold-layer-cache-base:%s", baseDigest.Hex)
+	baseRef, err := name.ParseReference(baseTag)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := daemon.Write(baseRef, base); err != nil {
+		return nil, errors.Wrap(err, "staging base image for instruction build")
+	}
+
+	stepTag := fmt.Sprintf("skaffold-layer-cache-step:%s", baseDigest.Hex)
+	dockerfile := fmt.Sprintf("FROM %s\n%s\n", baseTag, instr.Command)
+
+	if err := b.localDocker.BuildFromDockerfile(ctx, out, workspace, dockerfile, stepTag); err != nil {
+		return nil, errors.Wrapf(err, "running instruction %q", instr.Command)
+	}
+
+	stepRef, err := name.ParseReference(stepTag)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.Image(stepRef)
+}
+
+// parseDockerfile splits path into its FROM image and the ordered
+// instructions that follow. Build args, multi-stage builds and line
+// continuations aren't handled; this covers the single-stage case
+// BuildWithLayerCache can actually execute one instruction at a time.
+func parseDockerfile(path string) (string, []Instruction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	var fromImage string
+	var instructions []Instruction
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if fromImage == "" {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+				return "", nil, errors.New("Dockerfile must start with a FROM instruction")
+			}
+			fromImage = fields[1]
+			continue
+		}
+
+		instructions = append(instructions, Instruction{Command: line})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+
+	return fromImage, instructions, nil
+}
+
+// contextDigest hashes the relative path and mtime of every file under
+// workspace, standing in for a digest of the files each instruction's
+// COPY/ADD actually reads until per-instruction dependency resolution
+// exists.
+func contextDigest(workspace string) (string, error) {
+	h := sha256.New()
+
+	err := filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// LocalDaemon talks to the local Docker daemon to build, tag, push, pull and
+// inspect images.
+type LocalDaemon interface {
+	Build(ctx context.Context, out io.Writer, workspace, tag string) error
+	// BuildFromDockerfile builds workspace using dockerfile in place of
+	// whatever Dockerfile lives on disk there, e.g. to run a single
+	// instruction against a staged base image. workspace is still sent as
+	// the build context, so COPY/ADD sources resolve normally.
+	BuildFromDockerfile(ctx context.Context, out io.Writer, workspace, dockerfile, tag string) error
+	Pull(ctx context.Context, out io.Writer, ref string) error
+	ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error)
+}
+
+type localDaemon struct {
+	apiClient          client.CommonAPIClient
+	insecureRegistries map[string]bool
+}
+
+// NewAPIClient returns a LocalDaemon talking to the local Docker daemon.
+func NewAPIClient(prune bool, insecureRegistries map[string]bool) (LocalDaemon, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+
+	return &localDaemon{apiClient: cli, insecureRegistries: insecureRegistries}, nil
+}
+
+func (d *localDaemon) Build(ctx context.Context, out io.Writer, workspace, tag string) error {
+	buildContext, err := tarDirectory(workspace)
+	if err != nil {
+		return errors.Wrap(err, "tarring build context")
+	}
+
+	resp, err := d.apiClient.ImageBuild(ctx, buildContext, types.ImageBuildOptions{Tags: []string{tag}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+func (d *localDaemon) BuildFromDockerfile(ctx context.Context, out io.Writer, workspace, dockerfile, tag string) error {
+	buildContext, err := tarDirectoryWithDockerfile(workspace, dockerfile)
+	if err != nil {
+		return errors.Wrap(err, "tarring build context")
+	}
+
+	resp, err := d.apiClient.ImageBuild(ctx, buildContext, types.ImageBuildOptions{Tags: []string{tag}, Dockerfile: "Dockerfile"})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// tarDirectory archives workspace's own Dockerfile and files as-is.
+func tarDirectory(workspace string) (io.Reader, error) {
+	return tarFiles(workspace, "")
+}
+
+// tarDirectoryWithDockerfile archives workspace's files, substituting
+// dockerfile in place of whatever Dockerfile is on disk there.
+func tarDirectoryWithDockerfile(workspace, dockerfile string) (io.Reader, error) {
+	return tarFiles(workspace, dockerfile)
+}
+
+func tarFiles(workspace, dockerfileOverride string) (io.Reader, error) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+
+	err := filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return err
+		}
+
+		if dockerfileOverride != "" && rel == "Dockerfile" {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return writeTarEntry(w, rel, contents)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dockerfileOverride != "" {
+		if err := writeTarEntry(w, "Dockerfile", []byte(dockerfileOverride)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func writeTarEntry(w *tar.Writer, name string, contents []byte) error {
+	if err := w.WriteHeader(&tar.Header{Name: name, Size: int64(len(contents)), Mode: 0644}); err != nil {
+		return err
+	}
+	_, err := w.Write(contents)
+	return err
+}
+
+func (d *localDaemon) Pull(ctx context.Context, out io.Writer, ref string) error {
+	rc, err := d.apiClient.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+func (d *localDaemon) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	return d.apiClient.ImageInspectWithRaw(ctx, ref)
+}
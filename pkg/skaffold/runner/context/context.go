@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+)
+
+// RunContext holds the state shared across a single skaffold run: the
+// user's CLI options plus anything derived from the project config that
+// build/deploy/cache code needs.
+type RunContext struct {
+	Opts               SkaffoldOptions
+	InsecureRegistries map[string]bool
+}
+
+// SkaffoldOptions holds the user-supplied configuration collected from
+// skaffold's CLI flags. See cmd/skaffold/app/flags for where these are
+// registered.
+type SkaffoldOptions struct {
+	CacheArtifacts bool
+	CacheFile      string
+
+	// CacheRepo is the remote, registry-backed cache target configured via
+	// --cache-repo (see pkg/skaffold/build/cache/remote.go). Empty disables
+	// the remote cache.
+	CacheRepo string
+
+	// CacheBackend selects the in-memory store that fronts the persisted
+	// cache file, configured via cache.backend ("lru" or "" to disable).
+	CacheBackend string
+	// CacheMaxEntries and CacheMaxSize bound the in-memory LRU cache,
+	// configured via cache.max-entries and cache.max-size.
+	CacheMaxEntries int
+	CacheMaxSize    int64
+	// CacheTTL bounds how long a cache entry, in-memory or on disk, is
+	// trusted before it's dropped, configured via --cache-ttl.
+	CacheTTL time.Duration
+
+	prune bool
+}
+
+// Prune reports whether built images should be pruned from the local
+// Docker daemon after use.
+func (o SkaffoldOptions) Prune() bool {
+	return o.prune
+}
+
+// ApplyCacheConfig overlays cfg, the cache: stanza read from skaffold.yaml,
+// onto o. Only fields still at their zero value are filled in, so a
+// --cache-backend/--cache-max-size/--cache-ttl flag the user actually typed
+// always wins over the project config.
+func (o *SkaffoldOptions) ApplyCacheConfig(cfg latest.CacheConfig) error {
+	if o.CacheBackend == "" {
+		o.CacheBackend = cfg.Backend
+	}
+	if o.CacheMaxSize == 0 {
+		o.CacheMaxSize = cfg.MaxSize
+	}
+	if o.CacheTTL == 0 && cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return errors.Wrap(err, "parsing cache.ttl")
+		}
+		o.CacheTTL = ttl
+	}
+	return nil
+}
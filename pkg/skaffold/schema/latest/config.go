@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latest
+
+// Artifact describes one image to be built from source.
+type Artifact struct {
+	// ImageName is the name of the image to build.
+	ImageName string `yaml:"image,omitempty"`
+	// Workspace is the directory containing the artifact's sources.
+	Workspace string `yaml:"context,omitempty"`
+	// Output configures properties of the image this artifact builds.
+	Output OutputConfig `yaml:"output,omitempty"`
+}
+
+// OutputConfig configures properties of a built image.
+type OutputConfig struct {
+	// Timestamp controls what Created timestamp the built image (and its
+	// layer history) gets: "Zero", "SourceTimestamp" or "BuildTimestamp"
+	// (the default). See cache.ResolveOutputTimestamp/ApplyOutputTimestamp.
+	Timestamp string `yaml:"timestamp,omitempty"`
+}
+
+// CacheConfig configures skaffold's local build cache. It corresponds to
+// the cache: stanza in skaffold.yaml. The equivalent --cache-* CLI flags
+// (see cmd/skaffold/app/flags) take precedence over these values when
+// explicitly set; see runcontext.SkaffoldOptions.ApplyCacheConfig.
+type CacheConfig struct {
+	// MaxSize bounds the total byte size of the in-memory cache backend (0
+	// is unbounded).
+	MaxSize int64 `yaml:"max-size,omitempty"`
+	// TTL drops cache entries older than this duration, whether held
+	// in-memory or on disk, e.g. "24h" (0 or unset disables expiry).
+	TTL string `yaml:"ttl,omitempty"`
+	// Backend selects the in-memory store that fronts the persisted cache
+	// file: "lru" or "" to disable.
+	Backend string `yaml:"backend,omitempty"`
+}
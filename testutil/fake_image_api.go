@@ -23,6 +23,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
@@ -42,11 +43,55 @@ type FakeAPIClient struct {
 	ErrImagePull    bool
 	ErrStream       bool
 
+	// RemoteEntries simulates entries already mirrored into a remote,
+	// registry-backed cache repo (keyed by artifact-hash), so cache tests
+	// can exercise the remote resolution path without a real registry. See
+	// Lookup/Store, which implement cache.RegistryClient against it.
+	RemoteEntries map[string]RemoteEntry
+
 	nextImageID  int
 	Tagged       []string
 	Pushed       []string
+	Pulled       []string
 	Built        []types.ImageBuildOptions
 	PushedImages []string
+
+	// CreatedAt records, per image ID, the effective Created timestamp the
+	// builder applied (e.g. via cache.ApplyOutputTimestamp), so tests can
+	// assert that a build's digest is deterministic across rebuilds.
+	CreatedAt map[string]time.Time
+}
+
+// SetCreatedAt records the effective Created timestamp for imageID, as if
+// the builder had rewritten the image config via cache.ApplyOutputTimestamp.
+func (f *FakeAPIClient) SetCreatedAt(imageID string, createdAt time.Time) {
+	if f.CreatedAt == nil {
+		f.CreatedAt = make(map[string]time.Time)
+	}
+	f.CreatedAt[imageID] = createdAt
+}
+
+// RemoteEntry is a remote cache entry, as simulated by RemoteEntries.
+type RemoteEntry struct {
+	Digest string
+	ID     string
+}
+
+// Lookup implements cache.RegistryClient against RemoteEntries, returning
+// the artifact digest/id mirrored under hash, so cache tests can exercise
+// the remote-cache resolution path without a real registry.
+func (f *FakeAPIClient) Lookup(repo, hash string) (digest, id string, found bool) {
+	entry, ok := f.RemoteEntries[hash]
+	return entry.Digest, entry.ID, ok
+}
+
+// Store implements cache.RegistryClient against RemoteEntries.
+func (f *FakeAPIClient) Store(repo, hash, digest, id string) error {
+	if f.RemoteEntries == nil {
+		f.RemoteEntries = make(map[string]RemoteEntry)
+	}
+	f.RemoteEntries[hash] = RemoteEntry{Digest: digest, ID: id}
+	return nil
 }
 
 type notFoundError struct {
@@ -155,6 +200,7 @@ func (f *FakeAPIClient) ImagePull(_ context.Context, ref string, _ types.ImagePu
 		return nil, fmt.Errorf("")
 	}
 
+	f.Pulled = append(f.Pulled, ref)
 	return f.body(""), nil
 }
 
@@ -0,0 +1,50 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutil
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+)
+
+// FakeLocalDaemon adapts a FakeAPIClient to docker.LocalDaemon, so cache and
+// builder tests can exercise Build/Pull/ImageInspectWithRaw without a real
+// Docker daemon. ImageInspectWithRaw is satisfied by the embedded
+// FakeAPIClient directly; only Build/Pull need translating to LocalDaemon's
+// narrower signatures.
+type FakeLocalDaemon struct {
+	*FakeAPIClient
+}
+
+func (f *FakeLocalDaemon) Build(ctx context.Context, out io.Writer, workspace, tag string) error {
+	_, err := f.FakeAPIClient.ImageBuild(ctx, nil, types.ImageBuildOptions{Tags: []string{tag}})
+	return err
+}
+
+// BuildFromDockerfile ignores dockerfile's content (the fake has no real
+// image store to build against) and just records tag as built, like Build.
+func (f *FakeLocalDaemon) BuildFromDockerfile(ctx context.Context, out io.Writer, workspace, dockerfile, tag string) error {
+	_, err := f.FakeAPIClient.ImageBuild(ctx, nil, types.ImageBuildOptions{Tags: []string{tag}})
+	return err
+}
+
+func (f *FakeLocalDaemon) Pull(ctx context.Context, out io.Writer, ref string) error {
+	_, err := f.FakeAPIClient.ImagePull(ctx, ref, types.ImagePullOptions{})
+	return err
+}